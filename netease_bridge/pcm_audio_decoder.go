@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// AudioFormat 标识嗅探出的音频容器/编码格式
+type AudioFormat int
+
+const (
+	FormatUnknown AudioFormat = iota
+	FormatFLAC
+	FormatMP3
+	FormatOpus
+	FormatVorbis
+)
+
+// formatProbeSize 是 DetectFormat / OpenStreaming 等待的最小头部字节数，
+// 足够覆盖 FLAC 的 "fLaC" 标记和 Ogg 第一个 page 里的 codec 标识
+const formatProbeSize = 64 * 1024
+
+// AudioDecoder 是所有格式解码器的统一接口，播放器只依赖它，
+// 不需要在调用点区分 FLAC/Opus/Vorbis，类似 MPD 的 decoder plugin 分发。
+// 本仓库没有实现它的 MP3 解码器，OpenStreaming 对 MP3 返回错误
+type AudioDecoder interface {
+	GetInfo() (sampleRate, channels int, totalFrames uint64)
+	ReadFrames(buffer []float32, framesToRead int) int
+	Seek(sampleIndex uint64) error
+	IsEOF() bool
+	Close()
+}
+
+// DetectFormat 通过嗅探 cachePath 开头的魔数判断音频格式
+func DetectFormat(cachePath string) (AudioFormat, error) {
+	file, err := os.Open(cachePath)
+	if err != nil {
+		return FormatUnknown, err
+	}
+	defer file.Close()
+
+	header := make([]byte, formatProbeSize)
+	n, err := io.ReadFull(file, header)
+	if n == 0 {
+		if err != nil && err != io.EOF {
+			return FormatUnknown, err
+		}
+		return FormatUnknown, errors.New("audio: empty file")
+	}
+	header = header[:n]
+
+	switch {
+	case len(header) >= 4 && string(header[0:4]) == "fLaC":
+		return FormatFLAC, nil
+	case len(header) >= 3 && string(header[0:3]) == "ID3":
+		return FormatMP3, nil
+	case len(header) >= 2 && isMPEGSync(header):
+		return FormatMP3, nil
+	case len(header) >= 4 && string(header[0:4]) == "OggS":
+		return detectOggCodec(header)
+	default:
+		return FormatUnknown, errors.New("audio: unrecognized format")
+	}
+}
+
+// isMPEGSync 判断是否以 MPEG 帧同步字 (11 位全 1) 开头
+func isMPEGSync(header []byte) bool {
+	return header[0] == 0xFF && header[1]&0xE0 == 0xE0
+}
+
+// detectOggCodec 在已读到的全部头部字节里（不只第一个 page）查找 Opus/Vorbis 的 codec 标识
+func detectOggCodec(header []byte) (AudioFormat, error) {
+	switch {
+	case bytes.Contains(header, []byte("OpusHead")):
+		return FormatOpus, nil
+	case bytes.Contains(header, []byte("vorbis")):
+		return FormatVorbis, nil
+	default:
+		return FormatUnknown, errors.New("audio: unrecognized ogg codec")
+	}
+}
+
+// OpenStreaming 根据嗅探结果挑选合适的流式解码器。
+// 对于需要完整容器头部才能解析的格式（FLAC、Ogg 系列），先通过
+// cache.EnsureRange 等待头部字节就绪，而不是像旧版 TryOpen 那样
+// 轮询文件大小
+func OpenStreaming(cache *AudioCache) (AudioDecoder, error) {
+	if err := cache.EnsureRange(0, formatProbeSize); err != nil {
+		return nil, err
+	}
+
+	format, err := DetectFormat(cache.GetCachePath())
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatFLAC:
+		return NewFlacSeekableDecoder(cache)
+	case FormatOpus:
+		return NewOpusStreamingDecoder(cache)
+	case FormatVorbis:
+		return NewVorbisStreamingDecoder(cache)
+	case FormatMP3:
+		return nil, errors.New("audio: mp3 streaming decoder not available in this build")
+	default:
+		return nil, errors.New("audio: unsupported format")
+	}
+}
+
+// OpenSubstream 和 OpenStreaming 类似，但只播放 [startMs, endMs) 区间，
+// 用于预览或者给循环播放的背景音乐做淡出；目前只有 FLAC 有对应的
+// substream 解码器，其它格式在这里显式报错，而不是静默退化成播放整首歌
+func OpenSubstream(cache *AudioCache, startMs, endMs uint64) (AudioDecoder, error) {
+	if err := cache.EnsureRange(0, formatProbeSize); err != nil {
+		return nil, err
+	}
+
+	format, err := DetectFormat(cache.GetCachePath())
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatFLAC:
+		return NewFlacSubstreamDecoder(cache, startMs, endMs)
+	default:
+		return nil, errors.New("audio: substream decoding not available for this format in this build")
+	}
+}
@@ -2,128 +2,593 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
 
-// AudioCache 管理音频文件的下载缓存
+const (
+	// downloadBlockSize 是每次 Range 请求获取的块大小
+	downloadBlockSize int64 = 64 * 1024 // 64KiB
+
+	// numDownloadWorkers 是并行拉取 Range 的 worker 数量
+	numDownloadWorkers = 4
+
+	// minReadAheadWindow / maxReadAheadWindow 限制预读窗口的大小
+	minReadAheadWindow  int64 = 256 * 1024      // 256KiB
+	maxReadAheadWindow  int64 = 8 * 1024 * 1024 // 8MiB
+	readAheadFactor           = 2.0             // 预读窗口 = 吞吐量 * 往返时延 * factor
+	defaultPingEstimate       = 200 * time.Millisecond
+
+	// maxFetchAttempts 是单个 Range 请求在放弃前允许的总尝试次数
+	maxFetchAttempts = 5
+	retryBaseDelay   = 500 * time.Millisecond
+	maxRetryDelay    = 10 * time.Second
+
+	// sidecarSaveInterval 限制 .ranges 侧车文件的最小写盘间隔，避免在
+	// c.mutex 持有期间对每个下载完成的 block 都同步写一次 JSON
+	sidecarSaveInterval = 2 * time.Second
+)
+
+// fetchPriority 决定 fetchJob 在队列中的处理顺序
+type fetchPriority int
+
+const (
+	priorityReadAhead fetchPriority = iota // 常规预读，可被 seek 打断
+	prioritySeek                           // 用户触发的 seek，必须优先满足
+)
+
+// fetchJob 是一次针对 [start, end) 的下载任务
+type fetchJob struct {
+	start, end int64
+	priority   fetchPriority
+	generation int64 // 创建该任务时的 seek 代数，只做记录；过期的预读任务由 RequestSeek 按 priority 丢弃
+	attempt    int   // 已经尝试过的次数，用于重试退避
+}
+
+// failedRange 记录某个区间在重试耗尽后的终态错误，只影响和它重叠的
+// EnsureRange 调用，不会波及文件里其它仍可正常下载的区间
+type failedRange struct {
+	start, end int64
+	err        error
+}
+
+// AudioCache 基于 HTTP Range 请求的分块下载缓存
+// 设计参考 librespot 的音频抓取方式：把远端文件按固定大小分块，
+// 用 RangeSet 记录已下载区间，多个 worker 并行拉取缺失的区间
 type AudioCache struct {
-	url         string
-	cacheFile   *os.File
-	cachePath   string
-	downloaded  int64
-	totalSize   int64
-	isComplete  bool
-	mutex       sync.RWMutex
-	ctx         context.Context
-	cancel      context.CancelFunc
-	onComplete  func() // 下载完成回调
-}
-
-// NewAudioCache 创建新的音频缓存
+	url       string
+	songId    int64
+	cacheFile *os.File
+	cachePath string
+
+	mutex     sync.Mutex
+	cond      *sync.Cond
+	ranges    *RangeSet
+	totalSize int64
+	etag      string
+	isOpen    bool  // HEAD 请求是否已确定 totalSize
+	active    bool  // 是否仍被播放器持有，供 AudioCacheManager 判断可否回收
+	openErr   error // HEAD 请求本身失败时的终态错误，只影响还在等 isOpen 的调用者
+
+	failedRanges []failedRange // 重试耗尽的区间及其终态错误，见 failedRange
+
+	lastSidecarSave time.Time // 上一次写侧车文件的时间，用于节流 saveSidecar
+
+	generation      int64 // 每次 RequestSeek 递增，写入新建的 fetchJob；调度时不读取它
+	readAheadOffset int64 // 下一次预读的起始位置
+
+	pingEstimate       time.Duration
+	throughputEstimate float64 // 字节/秒，指数移动平均
+
+	jobs     []fetchJob
+	jobCond  *sync.Cond
+	jobMutex sync.Mutex
+
+	client     *http.Client
+	ctx        context.Context
+	cancel     context.CancelFunc
+	onComplete func() // 下载完成回调
+
+	workersOnce sync.Once
+}
+
+// NewAudioCache 创建新的音频缓存，若磁盘上已有该 songId 的缓存文件和
+// 侧车 .ranges 元数据，会尝试在 initAndDownload 中校验后复用（断点续传）
 func NewAudioCache(url string, songId int64) (*AudioCache, error) {
-	// 创建缓存目录
 	cacheDir := filepath.Join(os.TempDir(), "chillpatcher_audio_cache")
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return nil, err
 	}
 
-	// 缓存文件路径
 	cachePath := filepath.Join(cacheDir, formatCacheFileName(songId))
 
-	// 创建或打开缓存文件
-	file, err := os.OpenFile(cachePath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	// 不再使用 O_TRUNC：保留跨进程重启的已下载数据
+	file, err := os.OpenFile(cachePath, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
 		return nil, err
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &AudioCache{
-		url:       url,
-		cacheFile: file,
-		cachePath: cachePath,
-		ctx:       ctx,
-		cancel:    cancel,
-	}, nil
+	c := &AudioCache{
+		url:          url,
+		songId:       songId,
+		cacheFile:    file,
+		cachePath:    cachePath,
+		ranges:       NewRangeSet(),
+		active:       true,
+		pingEstimate: defaultPingEstimate,
+		client: &http.Client{
+			Transport: &http.Transport{
+				ResponseHeaderTimeout: 30 * time.Second,
+				IdleConnTimeout:       90 * time.Second,
+			},
+		},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	c.cond = sync.NewCond(&c.mutex)
+	c.jobCond = sync.NewCond(&c.jobMutex)
+
+	c.loadSidecar()
+
+	return c, nil
 }
 
 func formatCacheFileName(songId int64) string {
 	return fmt.Sprintf("netease_%d.audio", songId)
 }
 
-// StartDownload 开始后台下载
-func (c *AudioCache) StartDownload() {
-	go c.downloadInBackground()
+func (c *AudioCache) sidecarPath() string {
+	return c.cachePath + ".ranges"
 }
 
-func (c *AudioCache) downloadInBackground() {
-	req, err := http.NewRequestWithContext(c.ctx, "GET", c.url, nil)
+// cacheMeta 是 .ranges 侧车文件的 JSON 结构，记录已下载区间及用于
+// 校验缓存是否仍与远端一致的 ETag / Content-Length
+type cacheMeta struct {
+	ETag          string      `json:"etag"`
+	ContentLength int64       `json:"content_length"`
+	Ranges        []byteRange `json:"ranges"`
+}
+
+// loadSidecar 尝试从磁盘恢复上一次的下载进度
+func (c *AudioCache) loadSidecar() {
+	data, err := os.ReadFile(c.sidecarPath())
 	if err != nil {
 		return
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0")
 
-	transport := &http.Transport{
-		ResponseHeaderTimeout: 30 * time.Second,
-		IdleConnTimeout:       90 * time.Second,
+	var meta cacheMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return
 	}
-	client := &http.Client{Transport: transport}
 
-	resp, err := client.Do(req)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.etag = meta.ETag
+	c.totalSize = meta.ContentLength
+	for _, r := range meta.Ranges {
+		c.ranges.Add(r.Start, r.End)
+	}
+}
+
+// saveSidecar 把当前下载进度持久化，供下次启动断点续传
+// 调用方需持有 c.mutex
+func (c *AudioCache) saveSidecar() {
+	meta := cacheMeta{
+		ETag:          c.etag,
+		ContentLength: c.totalSize,
+		Ranges:        c.ranges.Ranges(),
+	}
+	data, err := json.Marshal(meta)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
+	_ = os.WriteFile(c.sidecarPath(), data, 0644)
+	c.lastSidecarSave = time.Now()
+}
+
+// maybeSaveSidecar 和 saveSidecar 作用一样，但只有在距离上次写盘已经超过
+// sidecarSaveInterval 时才真正落盘：下载完成的 block 到达频率可能远高于
+// 这个间隔（4 个 worker、64KiB 一块），在 c.mutex 持有期间每块都同步写一次
+// JSON 会把 EnsureRange/RequestSeek 等其它调用者都卡在锁上。错过的写入会
+// 被下一次调用追上，Close 时还会强制落盘一次，不会丢最后的进度。
+// 调用方需持有 c.mutex
+func (c *AudioCache) maybeSaveSidecar() {
+	if time.Since(c.lastSidecarSave) < sidecarSaveInterval {
+		return
+	}
+	c.saveSidecar()
+}
+
+// StartDownload 探测文件大小并启动后台 worker 池
+func (c *AudioCache) StartDownload() {
+	go c.initAndDownload()
+}
+
+// initAndDownload 先发 HEAD 校验缓存是否仍与远端一致，再启动 worker 并排入首个预读任务
+func (c *AudioCache) initAndDownload() {
+	req, err := http.NewRequestWithContext(c.ctx, "HEAD", c.url, nil)
+	if err != nil {
+		c.failOpen(err)
+		return
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.failOpen(err)
+		return
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
 
 	c.mutex.Lock()
+	if c.totalSize > 0 && (c.totalSize != resp.ContentLength || (etag != "" && c.etag != "" && c.etag != etag)) {
+		// 远端内容已变化，已缓存的区间不再可信，全部丢弃重新下载
+		c.ranges.Reset()
+	}
 	c.totalSize = resp.ContentLength
+	c.etag = etag
+	c.isOpen = true
+	c.cond.Broadcast()
+	c.mutex.Unlock()
+
+	if c.totalSize <= 0 {
+		return
+	}
+
+	if err := c.cacheFile.Truncate(c.totalSize); err != nil {
+		return
+	}
+
+	c.workersOnce.Do(func() {
+		for i := 0; i < numDownloadWorkers; i++ {
+			go c.downloadWorker()
+		}
+	})
+
+	end := minInt64(computeReadAheadWindow(0, c.pingEstimate), c.totalSize)
+	c.enqueueJob(0, end, priorityReadAhead)
+}
+
+// failOpen 记录 HEAD 请求本身的终态错误并唤醒所有还在等 isOpen 变为
+// true 的 EnsureRange 调用者，避免它们在探测阶段就永久失败的情况下挂起
+func (c *AudioCache) failOpen(err error) {
+	c.mutex.Lock()
+	c.openErr = fmt.Errorf("audio cache: head request failed: %w", err)
+	c.cond.Broadcast()
 	c.mutex.Unlock()
+}
 
-	buffer := make([]byte, 32*1024) // 32KB buffer
+// downloadWorker 不断从队列中取出优先级最高的任务并拉取
+func (c *AudioCache) downloadWorker() {
 	for {
+		job, ok := c.nextJob()
+		if !ok {
+			return // ctx 已取消
+		}
+		c.fetchRange(job)
+	}
+}
+
+// nextJob 阻塞直到队列中有任务，取出优先级最高、其次最靠前的一个
+func (c *AudioCache) nextJob() (fetchJob, bool) {
+	c.jobMutex.Lock()
+	defer c.jobMutex.Unlock()
+
+	for len(c.jobs) == 0 {
 		select {
 		case <-c.ctx.Done():
-			return
+			return fetchJob{}, false
+		default:
+		}
+		c.jobCond.Wait()
+		select {
+		case <-c.ctx.Done():
+			return fetchJob{}, false
 		default:
 		}
+	}
 
-		n, err := resp.Body.Read(buffer)
-		if n > 0 {
-			c.mutex.Lock()
-			c.cacheFile.Write(buffer[:n])
-			c.downloaded += int64(n)
-			c.mutex.Unlock()
+	sort.SliceStable(c.jobs, func(i, j int) bool {
+		if c.jobs[i].priority != c.jobs[j].priority {
+			return c.jobs[i].priority > c.jobs[j].priority
 		}
+		return c.jobs[i].start < c.jobs[j].start
+	})
 
-		if err == io.EOF {
-			c.mutex.Lock()
-			c.isComplete = true
-			c.mutex.Unlock()
-			
-			// 调用完成回调
-			if c.onComplete != nil {
-				c.onComplete()
+	job := c.jobs[0]
+	c.jobs = c.jobs[1:]
+	return job, true
+}
+
+// enqueueJob 把缺失的子区间拆分入队，跳过已下载的部分
+func (c *AudioCache) enqueueJob(start, end int64, priority fetchPriority) {
+	c.mutex.Lock()
+	missing := c.ranges.Missing(start, end)
+	generation := c.generation
+	c.mutex.Unlock()
+
+	if len(missing) == 0 {
+		return
+	}
+
+	c.jobMutex.Lock()
+	for _, m := range missing {
+		for s := m.Start; s < m.End; s += downloadBlockSize {
+			e := s + downloadBlockSize
+			if e > m.End {
+				e = m.End
 			}
-			return
+			c.jobs = append(c.jobs, fetchJob{start: s, end: e, priority: priority, generation: generation})
+		}
+	}
+	c.jobMutex.Unlock()
+	c.jobCond.Broadcast()
+}
+
+// fetchRange 发起一次 Range 请求并写入缓存文件对应偏移；失败时按退避重试，
+// 重试次数耗尽后把错误记录到这个区间自己的 failedRange 上并唤醒等待者，
+// 其中只有等待这段字节的调用者会返回错误，避免 EnsureRange 永久挂起
+func (c *AudioCache) fetchRange(job fetchJob) {
+	if err := c.doFetchRange(job); err != nil {
+		c.handleFetchError(job, err)
+	}
+}
+
+// doFetchRange 是 fetchRange 的实际请求逻辑，失败时返回错误而不是直接丢弃任务
+func (c *AudioCache) doFetchRange(job fetchJob) error {
+	req, err := http.NewRequestWithContext(c.ctx, "GET", c.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", job.start, job.end-1))
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	ttfb := time.Since(start)
+
+	buf := make([]byte, job.end-job.start)
+	n, err := io.ReadFull(resp.Body, buf)
+	if n <= 0 {
+		if err == nil {
+			err = fmt.Errorf("audio cache: empty response for range [%d,%d)", job.start, job.end)
 		}
+		return err
+	}
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+
+	if _, err := c.cacheFile.WriteAt(buf[:n], job.start); err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
 
-		if err != nil {
+	c.mutex.Lock()
+	c.ranges.Add(job.start, job.start+int64(n))
+	c.clearFailedRange(job.start, job.start+int64(n))
+	c.updateEstimates(ttfb, int64(n), elapsed)
+	complete := c.isComplete()
+	if complete {
+		// 下载完成这个状态值得立刻落盘，不等节流窗口
+		c.saveSidecar()
+	} else {
+		c.maybeSaveSidecar()
+	}
+	c.cond.Broadcast()
+	c.mutex.Unlock()
+
+	if complete && c.onComplete != nil {
+		c.onComplete()
+	}
+	return nil
+}
+
+// handleFetchError 要么把任务重新排入队列稍后重试，要么在重试耗尽后
+// 把错误记在 [job.start, job.end) 上并唤醒所有阻塞在 EnsureRange 上的
+// 调用者；这个错误只会让读取这个区间的调用返回，其它区间不受影响
+func (c *AudioCache) handleFetchError(job fetchJob, err error) {
+	select {
+	case <-c.ctx.Done():
+		return
+	default:
+	}
+
+	if job.attempt+1 < maxFetchAttempts {
+		c.scheduleRetry(job)
+		return
+	}
+
+	c.mutex.Lock()
+	c.failedRanges = append(c.failedRanges, failedRange{
+		start: job.start,
+		end:   job.end,
+		err:   fmt.Errorf("audio cache: giving up on range [%d,%d) after %d attempts: %w", job.start, job.end, job.attempt+1, err),
+	})
+	c.cond.Broadcast()
+	c.mutex.Unlock()
+}
+
+// rangeFetchErr 调用方需持有 c.mutex：返回与 [offset, end) 重叠的终态
+// 下载错误（如果有），不重叠的失败区间不影响这次调用。fullyCovered 为
+// true 表示这个错误已经完整覆盖了 [offset, end)，调用方可以直接短路
+// 返回而不必再排一次必然失败的任务；只是部分重叠时仍需要继续走下载/
+// 等待路径去补齐没被失败覆盖的那部分字节
+func (c *AudioCache) rangeFetchErr(offset, end int64) (err error, fullyCovered bool) {
+	for _, f := range c.failedRanges {
+		if f.start < end && offset < f.end {
+			err = f.err
+			if f.start <= offset && f.end >= end {
+				return err, true
+			}
+		}
+	}
+	return err, false
+}
+
+// clearFailedRange 调用方需持有 c.mutex：[start, end) 之后补齐下载成功了，
+// 之前记在这个区间上的终态错误不再适用，让它能被后续的 EnsureRange 超越
+func (c *AudioCache) clearFailedRange(start, end int64) {
+	remaining := c.failedRanges[:0]
+	for _, f := range c.failedRanges {
+		if f.end <= start || f.start >= end {
+			remaining = append(remaining, f)
+		}
+	}
+	c.failedRanges = remaining
+}
+
+// scheduleRetry 在指数退避延迟后把任务重新放回队列
+func (c *AudioCache) scheduleRetry(job fetchJob) {
+	job.attempt++
+	delay := retryBaseDelay * time.Duration(int64(1)<<uint(job.attempt-1))
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	time.AfterFunc(delay, func() {
+		select {
+		case <-c.ctx.Done():
 			return
+		default:
+		}
+		c.jobMutex.Lock()
+		c.jobs = append(c.jobs, job)
+		c.jobMutex.Unlock()
+		c.jobCond.Broadcast()
+	})
+}
+
+// updateEstimates 用指数移动平均更新 ping / 吞吐量估计，调用方需持有 c.mutex
+func (c *AudioCache) updateEstimates(ttfb time.Duration, n int64, elapsed time.Duration) {
+	const alpha = 0.3
+
+	c.pingEstimate = time.Duration(float64(c.pingEstimate)*(1-alpha) + float64(ttfb)*alpha)
+
+	if elapsed > 0 {
+		instant := float64(n) / elapsed.Seconds()
+		if c.throughputEstimate == 0 {
+			c.throughputEstimate = instant
+		} else {
+			c.throughputEstimate = c.throughputEstimate*(1-alpha) + instant*alpha
 		}
 	}
 }
 
+// computeReadAheadWindow 根据吞吐量和往返时延估算预读窗口大小
+func computeReadAheadWindow(throughput float64, ping time.Duration) int64 {
+	window := int64(throughput * ping.Seconds() * readAheadFactor)
+	if window < minReadAheadWindow {
+		window = minReadAheadWindow
+	}
+	if window > maxReadAheadWindow {
+		window = maxReadAheadWindow
+	}
+	return window
+}
+
+// EnsureRange 阻塞直到 [offset, offset+length) 全部下载完成。调用方（比如
+// OpenStreaming 刚创建出的解码器）往往在 StartDownload 触发的 HEAD 请求
+// 返回之前就已经发起第一次读取，所以这里要等 isOpen 变为 true，而不是
+// 在第一次检查时就以「size not yet known」失败
+func (c *AudioCache) EnsureRange(offset, length int64) error {
+	c.mutex.Lock()
+	for !c.isOpen {
+		if c.openErr != nil {
+			err := c.openErr
+			c.mutex.Unlock()
+			return err
+		}
+		select {
+		case <-c.ctx.Done():
+			c.mutex.Unlock()
+			return fmt.Errorf("audio cache: closed while waiting for range")
+		default:
+		}
+		c.cond.Wait()
+	}
+	end := offset + length
+	if end > c.totalSize {
+		end = c.totalSize
+	}
+	if err, fullyCovered := c.rangeFetchErr(offset, end); fullyCovered {
+		c.mutex.Unlock()
+		return err
+	}
+	c.mutex.Unlock()
+
+	c.enqueueJob(offset, end, prioritySeek)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for !c.ranges.Contains(offset, end) {
+		if err, _ := c.rangeFetchErr(offset, end); err != nil {
+			return err
+		}
+		select {
+		case <-c.ctx.Done():
+			return fmt.Errorf("audio cache: closed while waiting for range")
+		default:
+		}
+		c.cond.Wait()
+	}
+	return nil
+}
+
+// RequestSeek 因为用户 seek 而重新排定下载优先级：
+// 丢弃队列中所有预读任务，转而优先下载 seek 目标附近的数据
+func (c *AudioCache) RequestSeek(offset int64) {
+	c.mutex.Lock()
+	c.generation++
+	c.readAheadOffset = offset
+	window := computeReadAheadWindow(c.throughputEstimate, c.pingEstimate)
+	end := offset + window
+	if c.totalSize > 0 && end > c.totalSize {
+		end = c.totalSize
+	}
+	c.mutex.Unlock()
+
+	c.jobMutex.Lock()
+	remaining := c.jobs[:0]
+	for _, j := range c.jobs {
+		if j.priority == priorityReadAhead {
+			continue // 丢弃过期的预读任务
+		}
+		remaining = append(remaining, j)
+	}
+	c.jobs = remaining
+	c.jobMutex.Unlock()
+
+	c.enqueueJob(offset, end, prioritySeek)
+}
+
+// isComplete 调用方需持有 c.mutex
+func (c *AudioCache) isComplete() bool {
+	return c.totalSize > 0 && c.ranges.Contains(0, c.totalSize)
+}
+
 // IsComplete 检查下载是否完成
 func (c *AudioCache) IsComplete() bool {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-	return c.isComplete
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.isComplete()
 }
 
 // GetCachePath 获取缓存文件路径
@@ -133,12 +598,12 @@ func (c *AudioCache) GetCachePath() string {
 
 // GetProgress 获取下载进度 (0-100)
 func (c *AudioCache) GetProgress() float64 {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 	if c.totalSize <= 0 {
 		return 0
 	}
-	return float64(c.downloaded) / float64(c.totalSize) * 100
+	return float64(c.ranges.TotalCovered()) / float64(c.totalSize) * 100
 }
 
 // SetOnComplete 设置下载完成回调
@@ -146,14 +611,59 @@ func (c *AudioCache) SetOnComplete(callback func()) {
 	c.onComplete = callback
 }
 
-// Close 关闭缓存并删除缓存文件
+// Close 停止下载并关闭文件句柄，但不再删除缓存文件，
+// 只是把该条目标记为 inactive；磁盘上的数据由 AudioCacheManager 统一回收
 func (c *AudioCache) Close() {
+	c.mutex.Lock()
+	c.active = false
+	c.saveSidecar()
+	c.mutex.Unlock()
+
 	c.cancel()
+	c.jobCond.Broadcast()
+	c.cond.Broadcast()
 	if c.cacheFile != nil {
 		c.cacheFile.Close()
 	}
-	// 删除缓存文件
-	if c.cachePath != "" {
-		os.Remove(c.cachePath)
+}
+
+// IsActive 是否仍被播放器持有
+func (c *AudioCache) IsActive() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.active
+}
+
+// IsClosed 判断该缓存是否已经被 Close 过；下载 worker 在 Close 后不会再恢复，
+// 调用方（AudioCacheManager.Open）需要据此决定是否要重新创建一个新的 AudioCache
+func (c *AudioCache) IsClosed() bool {
+	select {
+	case <-c.ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// DiskUsage 返回缓存文件的长度（os.Stat 的 Size）。initAndDownload Truncate
+// 之后它就是完整文件大小，未下载的稀疏空洞也计算在内
+func (c *AudioCache) DiskUsage() int64 {
+	info, err := os.Stat(c.cachePath)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// removeFiles 彻底删除缓存文件及侧车元数据，仅供 AudioCacheManager 在驱逐时调用
+func (c *AudioCache) removeFiles() {
+	os.Remove(c.cachePath)
+	os.Remove(c.sidecarPath())
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
 	}
+	return b
 }
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+const (
+	// defaultCacheQuotaBytes 是 chillpatcher_audio_cache 目录允许占用的默认磁盘配额
+	defaultCacheQuotaBytes int64 = 512 * 1024 * 1024 // 512MiB
+)
+
+// cacheEntry 是 AudioCacheManager 内部的 LRU 节点
+type cacheEntry struct {
+	songId int64
+	cache  *AudioCache
+}
+
+// AudioCacheManager 管理多首歌曲的 AudioCache，按 LRU 策略在超出磁盘配额时
+// 回收最久未使用且已不在播放（inactive）的缓存文件
+type AudioCacheManager struct {
+	mutex   sync.Mutex
+	quota   int64
+	entries map[int64]*list.Element // songId -> LRU 节点
+	lru     *list.List              // 最近使用的排在前面
+}
+
+// NewAudioCacheManager 创建一个磁盘配额为 quotaBytes 的缓存管理器
+// quotaBytes <= 0 时使用 defaultCacheQuotaBytes
+func NewAudioCacheManager(quotaBytes int64) *AudioCacheManager {
+	if quotaBytes <= 0 {
+		quotaBytes = defaultCacheQuotaBytes
+	}
+	return &AudioCacheManager{
+		quota:   quotaBytes,
+		entries: make(map[int64]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Open 获取（或创建）songId 对应的 AudioCache，并将其标记为最近使用
+// 新建的缓存会立即调用 StartDownload。如果已有条目的 AudioCache 之前被
+// Release/evict 关闭过（下载 worker 已经退出，不会再恢复），则丢弃它并
+// 重新创建一个新的 AudioCache —— 断点续传靠磁盘上的 .ranges 侧车文件完成
+func (m *AudioCacheManager) Open(url string, songId int64) (*AudioCache, error) {
+	m.mutex.Lock()
+	if elem, ok := m.entries[songId]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if !entry.cache.IsClosed() {
+			m.lru.MoveToFront(elem)
+			entry.cache.mutex.Lock()
+			entry.cache.active = true
+			entry.cache.mutex.Unlock()
+			m.mutex.Unlock()
+			return entry.cache, nil
+		}
+		m.lru.Remove(elem)
+		delete(m.entries, songId)
+	}
+	m.mutex.Unlock()
+
+	cache, err := NewAudioCache(url, songId)
+	if err != nil {
+		return nil, err
+	}
+	cache.StartDownload()
+
+	m.mutex.Lock()
+	elem := m.lru.PushFront(&cacheEntry{songId: songId, cache: cache})
+	m.entries[songId] = elem
+	m.mutex.Unlock()
+
+	m.evict()
+
+	return cache, nil
+}
+
+// Prefetch 为播放列表中的下一首歌提前开始下载，行为与 Open 完全一致：
+// 已存在则直接复用，不存在则创建并启动下载；条目同样会被标记为 active
+// 并移到 LRU 最前面
+func (m *AudioCacheManager) Prefetch(url string, songId int64) (*AudioCache, error) {
+	return m.Open(url, songId)
+}
+
+// Release 表示调用方不再需要 songId 对应的缓存（例如切歌），
+// 缓存条目仍保留在磁盘上，仅被标记为可回收
+func (m *AudioCacheManager) Release(songId int64) {
+	m.mutex.Lock()
+	elem, ok := m.entries[songId]
+	m.mutex.Unlock()
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*cacheEntry)
+	entry.cache.Close()
+
+	m.evict()
+}
+
+// evict 在总磁盘占用超过配额时，从 LRU 链表尾部开始清理 inactive 的缓存
+func (m *AudioCacheManager) evict() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	total := int64(0)
+	for elem := m.lru.Front(); elem != nil; elem = elem.Next() {
+		total += elem.Value.(*cacheEntry).cache.DiskUsage()
+	}
+
+	for total > m.quota {
+		elem := m.lru.Back()
+		if elem == nil {
+			break
+		}
+
+		entry := elem.Value.(*cacheEntry)
+		if entry.cache.IsActive() {
+			// 仍在播放中的条目不可回收，从它往前（更近使用过的方向）找第一个 inactive 的条目
+			evicted := false
+			for candidate := elem.Prev(); candidate != nil; candidate = candidate.Prev() {
+				if !candidate.Value.(*cacheEntry).cache.IsActive() {
+					elem = candidate
+					entry = elem.Value.(*cacheEntry)
+					evicted = true
+					break
+				}
+			}
+			if !evicted {
+				break // 没有可回收的条目了
+			}
+		}
+
+		total -= entry.cache.DiskUsage()
+		entry.cache.Close()
+		entry.cache.removeFiles()
+		delete(m.entries, entry.songId)
+		m.lru.Remove(elem)
+	}
+}
+
+// Close 关闭并保留所有缓存条目（不删除文件）
+func (m *AudioCacheManager) Close() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for elem := m.lru.Front(); elem != nil; elem = elem.Next() {
+		elem.Value.(*cacheEntry).cache.Close()
+	}
+}
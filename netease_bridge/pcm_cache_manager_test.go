@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// newFakeAudioCache 绕开 NewAudioCache（避免真的发网络请求），直接构造一个
+// 指向本地临时文件的 AudioCache，只用于驱动 AudioCacheManager 的 LRU/配额逻辑
+func newFakeAudioCache(t *testing.T, sizeBytes int64, active bool) *AudioCache {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cache.bin")
+	if err := os.WriteFile(path, make([]byte, sizeBytes), 0644); err != nil {
+		t.Fatalf("write fake cache file: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &AudioCache{
+		cachePath: path,
+		active:    active,
+		ranges:    NewRangeSet(),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	c.cond = sync.NewCond(&c.mutex)
+	c.jobCond = sync.NewCond(&c.jobMutex)
+	return c
+}
+
+// insertFakeEntry 把一个伪造的 AudioCache 以"最近使用"的身份插入管理器，
+// 等价于 Open 内部 PushFront 的那一步，但跳过创建真实 AudioCache
+func insertFakeEntry(m *AudioCacheManager, songId int64, cache *AudioCache) {
+	elem := m.lru.PushFront(&cacheEntry{songId: songId, cache: cache})
+	m.entries[songId] = elem
+}
+
+func TestAudioCacheManagerEvictsLeastRecentlyUsed(t *testing.T) {
+	m := NewAudioCacheManager(150)
+
+	insertFakeEntry(m, 1, newFakeAudioCache(t, 100, false)) // 最旧
+	insertFakeEntry(m, 2, newFakeAudioCache(t, 100, false))
+	insertFakeEntry(m, 3, newFakeAudioCache(t, 100, false)) // 最新
+
+	m.evict()
+
+	if _, ok := m.entries[1]; ok {
+		t.Errorf("songId 1 (least recently used) should have been evicted")
+	}
+	if _, ok := m.entries[2]; ok {
+		t.Errorf("songId 2 should have been evicted to make room under quota")
+	}
+	if _, ok := m.entries[3]; !ok {
+		t.Errorf("songId 3 (most recently used) should have survived")
+	}
+}
+
+func TestAudioCacheManagerSkipsActiveEntries(t *testing.T) {
+	m := NewAudioCacheManager(150)
+
+	insertFakeEntry(m, 1, newFakeAudioCache(t, 100, true)) // 最旧，但仍在播放
+	insertFakeEntry(m, 2, newFakeAudioCache(t, 100, false))
+	insertFakeEntry(m, 3, newFakeAudioCache(t, 100, false)) // 最新
+
+	m.evict()
+
+	if _, ok := m.entries[1]; !ok {
+		t.Errorf("songId 1 is active and must not be evicted even though it's least recently used")
+	}
+	if _, ok := m.entries[2]; ok {
+		t.Errorf("songId 2 should have been evicted in place of the active entry")
+	}
+	if _, ok := m.entries[3]; ok {
+		t.Errorf("songId 3 should also have been evicted, total usage is still over quota")
+	}
+}
+
+func TestAudioCacheManagerNoEvictionUnderQuota(t *testing.T) {
+	m := NewAudioCacheManager(1000)
+
+	insertFakeEntry(m, 1, newFakeAudioCache(t, 100, false))
+	insertFakeEntry(m, 2, newFakeAudioCache(t, 100, false))
+
+	m.evict()
+
+	if len(m.entries) != 2 {
+		t.Errorf("no entry should be evicted while under quota, got %d entries", len(m.entries))
+	}
+}
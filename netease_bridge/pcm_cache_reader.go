@@ -0,0 +1,51 @@
+package main
+
+import "os"
+
+// cacheReader 把 *AudioCache 包装成 io.ReadSeeker，供 flac/opus/vorbis 的
+// 解码库直接使用。initAndDownload 会把缓存文件先 Truncate 到完整大小，
+// 所以未下载到的字节在磁盘上只是空洞；每次 Read 前都会调用
+// cache.EnsureRange 阻塞等到所需字节真正下载完成，Seek 则转发给
+// cache.RequestSeek 调整下载优先级，这样解码库自己发起的 seek
+// （比如 flac.Stream.Seek 内部做的二分查找）也会顺带触发按需下载，
+// 而不是读到一堆零字节
+type cacheReader struct {
+	cache  *AudioCache
+	file   *os.File
+	offset int64
+}
+
+// newCacheReader 打开 cache 对应的缓存文件并包装成 cacheReader
+func newCacheReader(cache *AudioCache) (*cacheReader, error) {
+	file, err := os.Open(cache.GetCachePath())
+	if err != nil {
+		return nil, err
+	}
+	return &cacheReader{cache: cache, file: file}, nil
+}
+
+// Read 在读取前阻塞等待 [offset, offset+len(p)) 下载完成
+func (r *cacheReader) Read(p []byte) (int, error) {
+	if err := r.cache.EnsureRange(r.offset, int64(len(p))); err != nil {
+		return 0, err
+	}
+	n, err := r.file.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// Seek 定位底层文件，并把新的读取位置通报给 cache 以重排下载优先级
+func (r *cacheReader) Seek(offset int64, whence int) (int64, error) {
+	abs, err := r.file.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	r.offset = abs
+	r.cache.RequestSeek(abs)
+	return abs, nil
+}
+
+// Close 关闭底层文件
+func (r *cacheReader) Close() error {
+	return r.file.Close()
+}
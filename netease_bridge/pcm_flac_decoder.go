@@ -152,7 +152,7 @@ func (d *FlacStreamingDecoder) Close() {
 
 // FlacSeekableDecoder 可 Seek 的 FLAC 解码器
 type FlacSeekableDecoder struct {
-	file        *os.File
+	source      *cacheReader
 	stream      *flac.Stream
 	mutex       sync.Mutex
 	sampleRate  int
@@ -160,28 +160,30 @@ type FlacSeekableDecoder struct {
 	totalFrames uint64
 	currentPos  uint64
 	isReady     bool
-	isEOF       bool   // 流是否已结束
+	isEOF       bool // 流是否已结束
 	lastError   string
 	buffer      []float32 // 解码缓冲区
 	bufferStart uint64    // 缓冲区起始位置（样本）
 }
 
-// NewFlacSeekableDecoder 从缓存文件创建可 Seek 的 FLAC 解码器
-func NewFlacSeekableDecoder(cachePath string) (*FlacSeekableDecoder, error) {
-	file, err := os.Open(cachePath)
+// NewFlacSeekableDecoder 从 cache 创建可 Seek 的 FLAC 解码器。读写都经过
+// cacheReader，这样 flac.Stream.Seek 内部做的二分查找会阻塞等待目标字节
+// 下载完成，而不是读到 initAndDownload 预先 Truncate 出来的空洞
+func NewFlacSeekableDecoder(cache *AudioCache) (*FlacSeekableDecoder, error) {
+	source, err := newCacheReader(cache)
 	if err != nil {
 		return nil, err
 	}
 
 	// 使用 NewSeek 创建支持 Seek 的 Stream
-	stream, err := flac.NewSeek(file)
+	stream, err := flac.NewSeek(source)
 	if err != nil {
-		file.Close()
+		source.Close()
 		return nil, err
 	}
 
 	d := &FlacSeekableDecoder{
-		file:        file,
+		source:      source,
 		stream:      stream,
 		sampleRate:  int(stream.Info.SampleRate),
 		channels:    int(stream.Info.NChannels),
@@ -295,8 +297,201 @@ func (d *FlacSeekableDecoder) Close() {
 		d.stream.Close()
 		d.stream = nil
 	}
-	if d.file != nil {
-		d.file.Close()
-		d.file = nil
+	if d.source != nil {
+		d.source.Close()
+		d.source = nil
 	}
 }
+
+// FlacSubstreamDecoder 在 FlacSeekableDecoder 之上按时间窗口裁剪播放范围，
+// 灵感来自 ScummVM FLAC 输入流接受起止时间参数、但一直没实现循环的做法：
+// 这里补上了循环播放和淡出。通过 OpenSubstream 构造；本仓库目前没有 MP3
+// 解码器，所以 OpenSubstream 对 MP3 显式返回错误而不是静默退化成播放整首歌
+type FlacSubstreamDecoder struct {
+	decoder *FlacSeekableDecoder
+	mutex   sync.Mutex
+
+	startSample uint64 // 子流起点（样本）
+	endSample   uint64 // 子流终点（样本，不含）
+
+	loopConfigured bool
+	loopStart      uint64
+	loopEnd        uint64
+	loopRemaining  int // -1 表示无限循环，0 表示不再循环
+
+	fadeOutSamples uint64
+}
+
+// NewFlacSubstreamDecoder 从 cache 创建一个只播放 [startMs, endMs) 区间的 FLAC 解码器
+// endMs 为 0 表示播放到文件末尾
+func NewFlacSubstreamDecoder(cache *AudioCache, startMs, endMs uint64) (*FlacSubstreamDecoder, error) {
+	decoder, err := NewFlacSeekableDecoder(cache)
+	if err != nil {
+		return nil, err
+	}
+
+	startSample := msToSamples(startMs, decoder.sampleRate)
+	endSample := msToSamples(endMs, decoder.sampleRate)
+	if endSample == 0 || endSample > decoder.totalFrames {
+		endSample = decoder.totalFrames
+	}
+
+	if err := decoder.Seek(startSample); err != nil {
+		decoder.Close()
+		return nil, err
+	}
+
+	return &FlacSubstreamDecoder{
+		decoder:     decoder,
+		startSample: startSample,
+		endSample:   endSample,
+	}, nil
+}
+
+func msToSamples(ms uint64, sampleRate int) uint64 {
+	return ms * uint64(sampleRate) / 1000
+}
+
+// GetInfo 获取音频信息；totalFrames 是子流自身的长度（endSample-startSample），
+// 不是底层整首歌曲的长度
+func (d *FlacSubstreamDecoder) GetInfo() (sampleRate, channels int, totalFrames uint64) {
+	sampleRate, channels, _ = d.decoder.GetInfo()
+	return sampleRate, channels, d.endSample - d.startSample
+}
+
+// Seek 定位到子流内的相对样本位置（0 表示子流起点），会被夹到子流范围内
+func (d *FlacSubstreamDecoder) Seek(sampleIndex uint64) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	abs := d.startSample + sampleIndex
+	if abs > d.endSample {
+		abs = d.endSample
+	}
+	return d.decoder.Seek(abs)
+}
+
+// SetLoop 设置循环区间 [startMs, endMs) 和循环次数，count 为 -1 表示无限循环，0 表示关闭循环
+func (d *FlacSubstreamDecoder) SetLoop(startMs, endMs uint64, count int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	sampleRate, _, _ := d.decoder.GetInfo()
+	d.loopStart = msToSamples(startMs, sampleRate)
+	d.loopEnd = msToSamples(endMs, sampleRate)
+	if d.loopEnd == 0 || d.loopEnd > d.endSample {
+		d.loopEnd = d.endSample
+	}
+	d.loopRemaining = count
+	d.loopConfigured = count != 0
+}
+
+// SetFadeOut 设置在子流末尾最后 ms 毫秒内应用的线性淡出
+func (d *FlacSubstreamDecoder) SetFadeOut(ms int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	sampleRate, _, _ := d.decoder.GetInfo()
+	d.fadeOutSamples = msToSamples(uint64(ms), sampleRate)
+}
+
+// activeEndLocked 返回当前应该使用的终点：有循环时为循环终点，否则为子流终点
+// 调用方需持有 d.mutex
+func (d *FlacSubstreamDecoder) activeEndLocked() uint64 {
+	if d.loopConfigured && d.loopRemaining != 0 {
+		return d.loopEnd
+	}
+	return d.endSample
+}
+
+// advanceLoopLocked 在到达循环终点时跳回循环起点，返回是否成功继续播放
+// 调用方需持有 d.mutex
+func (d *FlacSubstreamDecoder) advanceLoopLocked() bool {
+	if !d.loopConfigured || d.loopRemaining == 0 {
+		return false
+	}
+	if d.loopRemaining > 0 {
+		d.loopRemaining--
+	}
+	if err := d.decoder.Seek(d.loopStart); err != nil {
+		return false
+	}
+	return true
+}
+
+// ReadFrames 读取 PCM 帧，在到达终点时按需循环，并在最后一段应用淡出
+func (d *FlacSubstreamDecoder) ReadFrames(buffer []float32, framesToRead int) int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	_, channels, _ := d.decoder.GetInfo()
+	framesWritten := 0
+
+	for framesWritten < framesToRead {
+		end := d.activeEndLocked()
+		// currentPos 是已解码到的位置，还留在 decoder.buffer 里的样本要减掉
+		pos := d.decoder.currentPos - uint64(len(d.decoder.buffer)/channels)
+		if pos >= end {
+			if !d.advanceLoopLocked() {
+				break
+			}
+			continue
+		}
+
+		remaining := end - pos
+		toRead := framesToRead - framesWritten
+		if uint64(toRead) > remaining {
+			toRead = int(remaining)
+		}
+
+		n := d.decoder.ReadFrames(buffer[framesWritten*channels:], toRead)
+		if n <= 0 {
+			break
+		}
+
+		terminal := !(d.loopConfigured && d.loopRemaining != 0)
+		if terminal && d.fadeOutSamples > 0 {
+			d.applyFadeOutLocked(buffer, channels, framesWritten, n, pos, end)
+		}
+
+		framesWritten += n
+		if n < toRead {
+			break // 底层流提前结束
+		}
+	}
+
+	if framesWritten == 0 {
+		return -2 // 与 FlacSeekableDecoder 约定一致：EOF
+	}
+	return framesWritten
+}
+
+// applyFadeOutLocked 对 buffer 中 [frameOffset, frameOffset+n) 范围内、
+// 落在终点前 fadeOutSamples 以内的帧应用线性增益
+func (d *FlacSubstreamDecoder) applyFadeOutLocked(buffer []float32, channels, frameOffset, n int, posBeforeChunk, end uint64) {
+	for i := 0; i < n; i++ {
+		samplePos := posBeforeChunk + uint64(i)
+		distToEnd := end - samplePos
+		if distToEnd >= d.fadeOutSamples {
+			continue
+		}
+		gain := float32(distToEnd) / float32(d.fadeOutSamples)
+		for ch := 0; ch < channels; ch++ {
+			idx := (frameOffset+i)*channels + ch
+			buffer[idx] *= gain
+		}
+	}
+}
+
+// IsEOF 是否结束（循环关闭或次数已用完，且已到达子流终点）
+func (d *FlacSubstreamDecoder) IsEOF() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	_, channels, _ := d.decoder.GetInfo()
+	pos := d.decoder.currentPos - uint64(len(d.decoder.buffer)/channels)
+	return !(d.loopConfigured && d.loopRemaining != 0) && pos >= d.endSample
+}
+
+// Close 关闭底层解码器
+func (d *FlacSubstreamDecoder) Close() {
+	d.decoder.Close()
+}
@@ -0,0 +1,81 @@
+package main
+
+import "testing"
+
+func TestMsToSamples(t *testing.T) {
+	cases := []struct {
+		ms         uint64
+		sampleRate int
+		want       uint64
+	}{
+		{0, 44100, 0},
+		{1000, 44100, 44100},
+		{500, 44100, 22050},
+		{1000, 48000, 48000},
+	}
+	for _, c := range cases {
+		if got := msToSamples(c.ms, c.sampleRate); got != c.want {
+			t.Errorf("msToSamples(%d, %d) = %d, want %d", c.ms, c.sampleRate, got, c.want)
+		}
+	}
+}
+
+func TestFlacSubstreamDecoderActiveEndLocked(t *testing.T) {
+	d := &FlacSubstreamDecoder{
+		endSample: 1000,
+	}
+	if got := d.activeEndLocked(); got != 1000 {
+		t.Errorf("activeEndLocked() with no loop = %d, want 1000 (substream end)", got)
+	}
+
+	d.loopConfigured = true
+	d.loopRemaining = 3
+	d.loopEnd = 600
+	if got := d.activeEndLocked(); got != 600 {
+		t.Errorf("activeEndLocked() with loop active = %d, want 600 (loop end)", got)
+	}
+
+	d.loopRemaining = 0
+	if got := d.activeEndLocked(); got != 1000 {
+		t.Errorf("activeEndLocked() with loop exhausted = %d, want 1000 (substream end)", got)
+	}
+}
+
+func TestFlacSubstreamDecoderApplyFadeOutLocked(t *testing.T) {
+	const channels = 2
+	d := &FlacSubstreamDecoder{fadeOutSamples: 4}
+
+	// 4 帧、2 声道，全部置 1.0，末尾 4 帧落在终点前 fadeOutSamples 范围内，
+	// 应该被线性衰减；起点(posBeforeChunk=96) 距离 end=100 还有 4 帧
+	buffer := make([]float32, 4*channels)
+	for i := range buffer {
+		buffer[i] = 1.0
+	}
+
+	d.applyFadeOutLocked(buffer, channels, 0, 4, 96, 100)
+
+	// frame 0 (samplePos=96, distToEnd=4) 正好在淡出窗口边界上，增益为 4/4=1.0
+	if buffer[0] != 1.0 || buffer[1] != 1.0 {
+		t.Errorf("frame 0 = %v, want gain 1.0 (at the edge of the fade window)", buffer[0:2])
+	}
+	// frame 3 (samplePos=99, distToEnd=1) 增益为 1/4
+	want := float32(1.0) / 4.0
+	if buffer[6] != want || buffer[7] != want {
+		t.Errorf("frame 3 = %v, want gain %v", buffer[6:8], want)
+	}
+}
+
+func TestFlacSubstreamDecoderApplyFadeOutLockedOutsideWindow(t *testing.T) {
+	const channels = 1
+	d := &FlacSubstreamDecoder{fadeOutSamples: 2}
+
+	buffer := []float32{1.0, 1.0, 1.0}
+	// posBeforeChunk=0, end=100：这 3 帧都远离终点，不应该被改动
+	d.applyFadeOutLocked(buffer, channels, 0, 3, 0, 100)
+
+	for i, v := range buffer {
+		if v != 1.0 {
+			t.Errorf("buffer[%d] = %v, want unchanged 1.0 (outside fade window)", i, v)
+		}
+	}
+}
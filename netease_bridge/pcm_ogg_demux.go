@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// errBadOggPage 表示读到的数据不是一个合法的 Ogg page
+var errBadOggPage = errors.New("ogg: invalid page header")
+
+// oggPacketReader 是一个极简的 Ogg 分帧器：只负责把 page 拼接还原成
+// 完整的 packet，不关心具体编解码器。用于给 OpusStreamingDecoder
+// 提供原始 packet，真正的解码交给 hraban/opus
+type oggPacketReader struct {
+	r          io.Reader
+	haveSerial bool
+	serial     uint32
+	continued  []byte   // 跨 page 还未拼完的 packet
+	queue      [][]byte // 一个 page 内一次性解出的多个 packet
+}
+
+func newOggPacketReader(r io.Reader) *oggPacketReader {
+	return &oggPacketReader{r: r}
+}
+
+// nextPacket 返回下一个完整 packet；一个逻辑流里的 chained/无关序列号的
+// page 会被跳过
+func (d *oggPacketReader) nextPacket() ([]byte, error) {
+	if len(d.queue) > 0 {
+		p := d.queue[0]
+		d.queue = d.queue[1:]
+		return p, nil
+	}
+
+	for {
+		packets, serial, err := d.readPage()
+		if err != nil {
+			return nil, err
+		}
+
+		if !d.haveSerial {
+			d.serial = serial
+			d.haveSerial = true
+		} else if serial != d.serial {
+			continue // 忽略其他逻辑流
+		}
+
+		if len(packets) == 0 {
+			continue // 本页的数据全部延续到下一页，还没有凑出完整 packet
+		}
+
+		d.queue = packets[1:]
+		return packets[0], nil
+	}
+}
+
+// readPage 读取一个 Ogg page，返回其中凑满的 packet 列表
+func (d *oggPacketReader) readPage() ([][]byte, uint32, error) {
+	header := make([]byte, 27)
+	if _, err := io.ReadFull(d.r, header); err != nil {
+		return nil, 0, err
+	}
+	if string(header[0:4]) != "OggS" {
+		return nil, 0, errBadOggPage
+	}
+
+	serial := binary.LittleEndian.Uint32(header[14:18])
+	numSegments := int(header[26])
+
+	segmentTable := make([]byte, numSegments)
+	if _, err := io.ReadFull(d.r, segmentTable); err != nil {
+		return nil, 0, err
+	}
+
+	pageLen := 0
+	for _, s := range segmentTable {
+		pageLen += int(s)
+	}
+	payload := make([]byte, pageLen)
+	if _, err := io.ReadFull(d.r, payload); err != nil {
+		return nil, 0, err
+	}
+
+	cur := d.continued
+	d.continued = nil
+
+	var packets [][]byte
+	offset := 0
+	for _, s := range segmentTable {
+		cur = append(cur, payload[offset:offset+int(s)]...)
+		offset += int(s)
+		if s < 255 {
+			packets = append(packets, cur)
+			cur = nil
+		}
+	}
+	if cur != nil {
+		d.continued = cur // 最后一个 segment 长度为 255，packet 还没结束
+	}
+
+	return packets, serial, nil
+}
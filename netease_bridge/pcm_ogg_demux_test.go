@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// buildOggPage 按 Ogg page 格式拼出一页原始字节：segmentTable 是 lacing
+// 值序列，payload 是这些 segment 按顺序拼起来的字节内容。
+// 调用方保证每个 packet 本身的分段已经算好（见 splitIntoSegments）。
+func buildOggPage(serial uint32, segmentTable []byte, payload []byte) []byte {
+	header := make([]byte, 27)
+	copy(header[0:4], "OggS")
+	header[4] = 0 // version
+	header[5] = 0 // header type
+	// granule position (8 bytes), checksum (4 bytes) 未使用，留零即可
+	header[14] = byte(serial)
+	header[15] = byte(serial >> 8)
+	header[16] = byte(serial >> 16)
+	header[17] = byte(serial >> 24)
+	header[26] = byte(len(segmentTable))
+
+	page := append([]byte{}, header...)
+	page = append(page, segmentTable...)
+	page = append(page, payload...)
+	return page
+}
+
+// splitIntoSegments 把一个 packet 按 Ogg 的 lacing 规则切成 255 字节一段的
+// segment table，最后一段如果恰好是 255 的倍数需要补一个长度为 0 的 segment
+// 表示 packet 结束
+func splitIntoSegments(packet []byte) []byte {
+	var table []byte
+	n := len(packet)
+	for n >= 255 {
+		table = append(table, 255)
+		n -= 255
+	}
+	table = append(table, byte(n))
+	return table
+}
+
+func TestOggPacketReaderSinglePage(t *testing.T) {
+	packet := []byte("hello opus packet")
+	page := buildOggPage(42, splitIntoSegments(packet), packet)
+
+	r := newOggPacketReader(bytes.NewReader(page))
+	got, err := r.nextPacket()
+	if err != nil {
+		t.Fatalf("nextPacket() error = %v", err)
+	}
+	if !bytes.Equal(got, packet) {
+		t.Errorf("nextPacket() = %q, want %q", got, packet)
+	}
+}
+
+func TestOggPacketReaderMultiplePacketsInOnePage(t *testing.T) {
+	p1 := []byte("first")
+	p2 := []byte("second")
+
+	var segmentTable, payload []byte
+	segmentTable = append(segmentTable, splitIntoSegments(p1)...)
+	payload = append(payload, p1...)
+	segmentTable = append(segmentTable, splitIntoSegments(p2)...)
+	payload = append(payload, p2...)
+
+	page := buildOggPage(1, segmentTable, payload)
+	r := newOggPacketReader(bytes.NewReader(page))
+
+	for _, want := range [][]byte{p1, p2} {
+		got, err := r.nextPacket()
+		if err != nil {
+			t.Fatalf("nextPacket() error = %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("nextPacket() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestOggPacketReaderPacketSpanningTwoPages(t *testing.T) {
+	// 构造一个 255+4 字节的 packet：第一页只有一个长度为 255 的 segment
+	// （表示延续），紧接着的第二页用 "tail" 这个 4 字节的 segment 收尾
+	part1 := bytes.Repeat([]byte{0xAB}, 255)
+	part2 := []byte("tail")
+	full := append(append([]byte{}, part1...), part2...)
+
+	page1 := buildOggPage(7, []byte{255}, part1)
+	page2 := buildOggPage(7, splitIntoSegments(part2), part2)
+
+	r := newOggPacketReader(bytes.NewReader(append(page1, page2...)))
+	got, err := r.nextPacket()
+	if err != nil {
+		t.Fatalf("nextPacket() error = %v", err)
+	}
+	if !bytes.Equal(got, full) {
+		t.Errorf("nextPacket() spanning two pages = %q, want %q", got, full)
+	}
+}
+
+func TestOggPacketReaderSkipsOtherSerial(t *testing.T) {
+	mine := []byte("mine")
+	other := []byte("other-stream")
+	pageOther := buildOggPage(2, splitIntoSegments(other), other)
+	pageMine := buildOggPage(1, splitIntoSegments(mine), mine)
+
+	// 第一页确立逻辑流的序列号；随后一个不同序列号的页必须被忽略
+	r := newOggPacketReader(bytes.NewReader(append(pageMine, pageOther...)))
+
+	got, err := r.nextPacket()
+	if err != nil {
+		t.Fatalf("nextPacket() error = %v", err)
+	}
+	if !bytes.Equal(got, mine) {
+		t.Errorf("nextPacket() = %q, want %q", got, mine)
+	}
+
+	if _, err := r.nextPacket(); err == nil {
+		t.Errorf("nextPacket() after foreign-serial page = nil error, want EOF/error")
+	}
+}
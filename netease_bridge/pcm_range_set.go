@@ -0,0 +1,121 @@
+package main
+
+// byteRange 表示一个左闭右开的字节区间 [Start, End)
+type byteRange struct {
+	Start int64
+	End   int64
+}
+
+// RangeSet 维护一组已排序、互不重叠的字节区间
+// 用于记录音频文件中哪些字节范围已经下载完成
+type RangeSet struct {
+	ranges []byteRange
+}
+
+// NewRangeSet 创建一个空的 RangeSet
+func NewRangeSet() *RangeSet {
+	return &RangeSet{}
+}
+
+// Add 将 [start, end) 合并进已有区间，自动与相邻/重叠区间合并
+func (s *RangeSet) Add(start, end int64) {
+	if end <= start {
+		return
+	}
+
+	merged := make([]byteRange, 0, len(s.ranges)+1)
+	inserted := false
+
+	for _, r := range s.ranges {
+		if inserted || r.End < start {
+			// 当前区间在新区间之前，且不相邻（相邻/重叠的情况落入下面的合并分支）
+			merged = append(merged, r)
+			continue
+		}
+
+		if r.Start > end {
+			// 当前区间在新区间之后，先落地新区间
+			merged = append(merged, byteRange{start, end})
+			inserted = true
+			merged = append(merged, r)
+			continue
+		}
+
+		// 重叠或相邻，合并到新区间中
+		if r.Start < start {
+			start = r.Start
+		}
+		if r.End > end {
+			end = r.End
+		}
+	}
+
+	if !inserted {
+		merged = append(merged, byteRange{start, end})
+	}
+
+	s.ranges = merged
+}
+
+// Contains 判断 [start, end) 是否已被完全覆盖
+func (s *RangeSet) Contains(start, end int64) bool {
+	if end <= start {
+		return true
+	}
+	for _, r := range s.ranges {
+		if r.Start <= start && r.End >= end {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing 返回 [start, end) 中尚未被覆盖的子区间列表
+func (s *RangeSet) Missing(start, end int64) []byteRange {
+	if end <= start {
+		return nil
+	}
+
+	var missing []byteRange
+	cursor := start
+
+	// s.ranges 按 Start 升序排列，逐个裁剪
+	for _, r := range s.ranges {
+		if r.End <= cursor || r.Start >= end {
+			continue
+		}
+		if r.Start > cursor {
+			missing = append(missing, byteRange{cursor, r.Start})
+		}
+		if r.End > cursor {
+			cursor = r.End
+		}
+	}
+
+	if cursor < end {
+		missing = append(missing, byteRange{cursor, end})
+	}
+
+	return missing
+}
+
+// Ranges 返回内部区间列表的快照，主要用于持久化
+func (s *RangeSet) Ranges() []byteRange {
+	out := make([]byteRange, len(s.ranges))
+	copy(out, s.ranges)
+	return out
+}
+
+// Reset 清空所有已记录的区间
+func (s *RangeSet) Reset() {
+	s.ranges = nil
+}
+
+// TotalCovered 返回已下载的总字节数
+func (s *RangeSet) TotalCovered() int64 {
+	var total int64
+	for _, r := range s.ranges {
+		total += r.End - r.Start
+	}
+	return total
+}
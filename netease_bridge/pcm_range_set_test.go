@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRangeSetAddMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		adds   []byteRange
+		expect []byteRange
+	}{
+		{
+			name:   "disjoint ranges stay separate",
+			adds:   []byteRange{{0, 10}, {20, 30}},
+			expect: []byteRange{{0, 10}, {20, 30}},
+		},
+		{
+			name:   "overlapping ranges merge",
+			adds:   []byteRange{{0, 10}, {5, 20}},
+			expect: []byteRange{{0, 20}},
+		},
+		{
+			name:   "adjacent ranges merge",
+			adds:   []byteRange{{0, 10}, {10, 20}},
+			expect: []byteRange{{0, 20}},
+		},
+		{
+			name:   "insertion in the middle keeps order",
+			adds:   []byteRange{{0, 10}, {30, 40}, {15, 25}},
+			expect: []byteRange{{0, 10}, {15, 25}, {30, 40}},
+		},
+		{
+			name:   "range bridging two existing ones merges all three",
+			adds:   []byteRange{{0, 10}, {30, 40}, {10, 30}},
+			expect: []byteRange{{0, 40}},
+		},
+		{
+			name:   "empty or inverted range is a no-op",
+			adds:   []byteRange{{0, 10}, {20, 20}, {30, 25}},
+			expect: []byteRange{{0, 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewRangeSet()
+			for _, r := range tt.adds {
+				s.Add(r.Start, r.End)
+			}
+			if got := s.Ranges(); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("Ranges() = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestRangeSetContains(t *testing.T) {
+	s := NewRangeSet()
+	s.Add(0, 10)
+	s.Add(20, 30)
+
+	cases := []struct {
+		start, end int64
+		want       bool
+	}{
+		{0, 10, true},
+		{2, 8, true},
+		{0, 11, false},
+		{10, 20, false},
+		{20, 30, true},
+		{5, 5, true}, // empty range is trivially covered
+	}
+
+	for _, c := range cases {
+		if got := s.Contains(c.start, c.end); got != c.want {
+			t.Errorf("Contains(%d, %d) = %v, want %v", c.start, c.end, got, c.want)
+		}
+	}
+}
+
+func TestRangeSetMissing(t *testing.T) {
+	s := NewRangeSet()
+	s.Add(10, 20)
+	s.Add(40, 50)
+
+	cases := []struct {
+		start, end int64
+		want       []byteRange
+	}{
+		{0, 60, []byteRange{{0, 10}, {20, 40}, {50, 60}}},
+		{10, 20, nil},
+		{15, 45, []byteRange{{20, 40}}},
+		{100, 100, nil},
+	}
+
+	for _, c := range cases {
+		if got := s.Missing(c.start, c.end); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Missing(%d, %d) = %v, want %v", c.start, c.end, got, c.want)
+		}
+	}
+}
+
+func TestRangeSetTotalCoveredAndReset(t *testing.T) {
+	s := NewRangeSet()
+	s.Add(0, 10)
+	s.Add(20, 25)
+
+	if got := s.TotalCovered(); got != 15 {
+		t.Errorf("TotalCovered() = %d, want 15", got)
+	}
+
+	s.Reset()
+	if got := s.TotalCovered(); got != 0 {
+		t.Errorf("TotalCovered() after Reset() = %d, want 0", got)
+	}
+	if got := s.Ranges(); len(got) != 0 {
+		t.Errorf("Ranges() after Reset() = %v, want empty", got)
+	}
+}